@@ -0,0 +1,108 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"sync"
+
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+// SpecCodec marshals and unmarshals a Spec to and from JSON, converts it to
+// a protobuf Any, and deep-copies it. The config package ships a codec for
+// google.golang.org/protobuf and plain JSON Specs, selected by reflecting
+// on the concrete Spec type (with a deprecated gogo/protobuf fallback, see
+// ToProtoGogo). Embedders with their own Spec representations (e.g. a
+// CUE-validated config, a JSON Schema document, or a MessagePack-encoded
+// Wasm plugin config) can install a SpecCodec for a specific
+// GroupVersionKind via RegisterCodec to take over marshaling for that type.
+//
+// ApplyJSONStrict must reject unknown fields the same way regardless of the
+// underlying wire format, so every SpecCodec is expected to fail rather than
+// silently ignore fields it does not recognize.
+type SpecCodec interface {
+	ToJSON(s Spec) ([]byte, error)
+	ApplyJSON(s Spec, js string) error
+	ApplyJSONStrict(s Spec, js string) error
+	ToProto(s Spec) (*anypb.Any, error)
+	DeepCopy(s Spec) Spec
+}
+
+var (
+	codecMu sync.RWMutex
+	codecs  = map[GroupVersionKind]SpecCodec{}
+)
+
+// RegisterCodec installs c as the SpecCodec used for gvk, overriding the
+// default protobuf/JSON codec selection in ToJSON, ApplyJSON,
+// ApplyJSONStrict, ToProto and DeepCopy for Configs of that
+// GroupVersionKind. Calling RegisterCodec again for the same gvk replaces
+// the previously registered codec.
+func RegisterCodec(gvk GroupVersionKind, c SpecCodec) {
+	codecMu.Lock()
+	defer codecMu.Unlock()
+	codecs[gvk] = c
+}
+
+// codecFor returns the SpecCodec registered for gvk, or the default codec
+// if none was registered.
+func codecFor(gvk GroupVersionKind) SpecCodec {
+	codecMu.RLock()
+	c, ok := codecs[gvk]
+	codecMu.RUnlock()
+	if ok {
+		return c
+	}
+	return defaultCodec{}
+}
+
+// codecIsDefault reports whether gvk has no custom SpecCodec registered via
+// RegisterCodec, i.e. whether it would use defaultCodec. Config.DeepCopyInto
+// uses this to decide whether a destination buffer can safely be handed to
+// the generated DeepCopyInto path: a custom codec's DeepCopy has no way to
+// accept one, so it must always allocate.
+func codecIsDefault(gvk GroupVersionKind) bool {
+	codecMu.RLock()
+	defer codecMu.RUnlock()
+	_, ok := codecs[gvk]
+	return !ok
+}
+
+// defaultCodec implements SpecCodec by reflecting on the concrete Spec
+// type, preserving the historical behavior of this package: protobuf
+// Messages are marshaled with protojson, gogo/protobuf Messages are
+// supported on a best-effort basis for one release cycle (see
+// ToProtoGogo), and anything else falls back to encoding/json.
+type defaultCodec struct{}
+
+func (defaultCodec) ToJSON(s Spec) ([]byte, error) {
+	return toJSON(s)
+}
+
+func (defaultCodec) ApplyJSON(s Spec, js string) error {
+	return applyJSON(s, js)
+}
+
+func (defaultCodec) ApplyJSONStrict(s Spec, js string) error {
+	return applyJSONStrict(s, js)
+}
+
+func (defaultCodec) ToProto(s Spec) (*anypb.Any, error) {
+	return toProto(s)
+}
+
+func (defaultCodec) DeepCopy(s Spec) Spec {
+	return deepCopy(s)
+}
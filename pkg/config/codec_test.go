@@ -0,0 +1,96 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"errors"
+	"testing"
+
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+// fakeCodec is a minimal SpecCodec used to verify that RegisterCodec/codecFor
+// dispatch by GroupVersionKind instead of always using defaultCodec.
+type fakeCodec struct{ name string }
+
+func (fakeCodec) ToJSON(s Spec) ([]byte, error)           { return []byte(`"fake"`), nil }
+func (fakeCodec) ApplyJSON(s Spec, js string) error       { return nil }
+func (fakeCodec) ApplyJSONStrict(s Spec, js string) error { return errors.New("fake: strict rejected") }
+func (fakeCodec) ToProto(s Spec) (*anypb.Any, error)      { return &anypb.Any{TypeUrl: "fake"}, nil }
+func (fakeCodec) DeepCopy(s Spec) Spec                    { return s }
+
+func TestRegisterCodec(t *testing.T) {
+	gvk := GroupVersionKind{Group: "test", Version: "v1", Kind: "Fake"}
+	other := GroupVersionKind{Group: "test", Version: "v1", Kind: "Other"}
+
+	if !codecIsDefault(gvk) {
+		t.Fatalf("codecIsDefault(%v) = false before registration, want true", gvk)
+	}
+	if _, ok := codecFor(gvk).(defaultCodec); !ok {
+		t.Fatalf("codecFor(%v) = %T before registration, want defaultCodec", gvk, codecFor(gvk))
+	}
+
+	RegisterCodec(gvk, fakeCodec{})
+	t.Cleanup(func() {
+		codecMu.Lock()
+		delete(codecs, gvk)
+		codecMu.Unlock()
+	})
+
+	if codecIsDefault(gvk) {
+		t.Errorf("codecIsDefault(%v) = true after registration, want false", gvk)
+	}
+	if _, ok := codecFor(gvk).(fakeCodec); !ok {
+		t.Errorf("codecFor(%v) = %T after registration, want fakeCodec", gvk, codecFor(gvk))
+	}
+
+	// An unrelated GVK must be unaffected.
+	if !codecIsDefault(other) {
+		t.Errorf("codecIsDefault(%v) = false, want true (registration is per-GVK)", other)
+	}
+	if _, ok := codecFor(other).(defaultCodec); !ok {
+		t.Errorf("codecFor(%v) = %T, want defaultCodec", other, codecFor(other))
+	}
+
+	// Re-registering the same GVK replaces the previous codec.
+	RegisterCodec(gvk, fakeCodec{name: "second"})
+	if c, ok := codecFor(gvk).(fakeCodec); !ok || c.name != "second" {
+		t.Errorf("codecFor(%v) after re-registration = %#v, want fakeCodec{name: \"second\"}", gvk, codecFor(gvk))
+	}
+}
+
+func TestDefaultCodecDelegatesToPackageFuncs(t *testing.T) {
+	s := &structTestSpec{Foo: "bar"}
+	var c SpecCodec = defaultCodec{}
+
+	js, err := c.ToJSON(s)
+	if err != nil {
+		t.Fatalf("ToJSON: %v", err)
+	}
+	want, err := toJSON(s)
+	if err != nil {
+		t.Fatalf("toJSON: %v", err)
+	}
+	if string(js) != string(want) {
+		t.Errorf("defaultCodec.ToJSON = %s, want %s", js, want)
+	}
+}
+
+// structTestSpec is a plain (non-proto) Spec used to exercise the
+// encoding/json fallback path shared by defaultCodec and the package-level
+// helpers.
+type structTestSpec struct {
+	Foo string `json:"foo"`
+}
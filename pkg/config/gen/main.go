@@ -0,0 +1,161 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Command gen emits a DeepCopyInto(dst interface{}) method for every
+// proto.Message Spec type in each target package, so pkg/config.DeepCopy
+// and Config.DeepCopyInto can clone a Spec into a caller-supplied
+// destination (e.g. pulled from a sync.Pool) with proto.Merge instead of
+// always allocating a fresh proto.Clone result.
+//
+// gen takes one go/packages pattern per positional argument and writes an
+// output file into each matching package's directory (defaulting to "."
+// if none are given), so it must be pointed at the packages that actually
+// define generated Istio protos and user-facing Specs -- pkg/config
+// itself only defines the Spec interface, not a concrete implementation
+// of it, so running gen there with no arguments is always a no-op. A
+// real invocation lives in each such package, e.g.:
+//
+//	//go:generate go run istio.io/istio/pkg/config/gen -output deepcopy_gen.go .
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/format"
+	"go/types"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"text/template"
+
+	"golang.org/x/tools/go/packages"
+)
+
+var outputFile = flag.String("output", "deepcopy_gen.go",
+	"base name of the file to write the generated DeepCopyInto methods to, created in each target package's directory")
+
+func main() {
+	flag.Parse()
+
+	patterns := flag.Args()
+	if len(patterns) == 0 {
+		patterns = []string{"."}
+	}
+
+	pkgs, err := packages.Load(&packages.Config{
+		Mode: packages.NeedName | packages.NeedFiles | packages.NeedTypes | packages.NeedTypesInfo | packages.NeedSyntax,
+	}, patterns...)
+	if err != nil {
+		log.Fatalf("gen: loading packages %v: %v", patterns, err)
+	}
+
+	var wrote int
+	for _, pkg := range pkgs {
+		if len(pkg.Errors) > 0 {
+			log.Fatalf("gen: package %s has errors: %v", pkg.PkgPath, pkg.Errors)
+		}
+		names := protoSpecTypes(pkg.Types.Scope())
+		if len(names) == 0 {
+			continue
+		}
+		if err := writePackage(pkg, names); err != nil {
+			log.Fatalf("gen: %v", err)
+		}
+		wrote++
+	}
+	if wrote == 0 {
+		log.Printf("gen: no proto.Message Spec types without a DeepCopyInto found in %v, nothing to do", patterns)
+	}
+}
+
+// writePackage renders and writes the DeepCopyInto methods for names into
+// pkg's directory.
+func writePackage(pkg *packages.Package, names []string) error {
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, struct {
+		Package string
+		Types   []string
+	}{Package: pkg.Name, Types: names}); err != nil {
+		return fmt.Errorf("executing template for %s: %w", pkg.PkgPath, err)
+	}
+
+	out, err := format.Source(buf.Bytes())
+	if err != nil {
+		return fmt.Errorf("formatting generated code for %s: %w", pkg.PkgPath, err)
+	}
+
+	if len(pkg.GoFiles) == 0 {
+		return fmt.Errorf("package %s has no source files to locate its directory from", pkg.PkgPath)
+	}
+	dest := filepath.Join(filepath.Dir(pkg.GoFiles[0]), *outputFile)
+	if err := os.WriteFile(dest, out, 0o644); err != nil {
+		return fmt.Errorf("writing %s: %w", dest, err)
+	}
+	log.Printf("gen: wrote %d DeepCopyInto method(s) to %s", len(names), dest)
+	return nil
+}
+
+// protoSpecTypes returns, in a stable sorted order, the exported named
+// struct types in scope whose pointer implements proto.Message
+// (structurally: a ProtoReflect() method) but doesn't yet have a
+// hand-written or previously generated DeepCopyInto method.
+func protoSpecTypes(scope *types.Scope) []string {
+	var names []string
+	for _, name := range scope.Names() {
+		tn, ok := scope.Lookup(name).(*types.TypeName)
+		if !ok || !tn.Exported() {
+			continue
+		}
+		named, ok := tn.Type().(*types.Named)
+		if !ok {
+			continue
+		}
+		if _, ok := named.Underlying().(*types.Struct); !ok {
+			continue
+		}
+		ptr := types.NewPointer(named)
+		if lookupMethod(ptr, "ProtoReflect") == nil {
+			continue
+		}
+		if lookupMethod(ptr, "DeepCopyInto") != nil {
+			continue
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func lookupMethod(t types.Type, name string) *types.Selection {
+	return types.NewMethodSet(t).Lookup(nil, name)
+}
+
+var tmpl = template.Must(template.New("deepcopy").Parse(`// Code generated by pkg/config/gen. DO NOT EDIT.
+
+package {{ .Package }}
+
+import "google.golang.org/protobuf/proto"
+{{ range .Types }}
+// DeepCopyInto copies {{ . }} into dst using proto.Merge, avoiding the
+// allocation a fresh proto.Clone would otherwise require. dst must be a
+// *{{ . }}; it is reset before the copy so stale fields from a pooled
+// destination don't leak through.
+func (in *{{ . }}) DeepCopyInto(dst interface{}) {
+	out := dst.(*{{ . }})
+	proto.Reset(out)
+	proto.Merge(out, in)
+}
+{{ end }}`))
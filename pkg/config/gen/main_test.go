@@ -0,0 +1,101 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"reflect"
+	"testing"
+)
+
+// checkSource type-checks src as a standalone package named "sample" and
+// returns its scope, so protoSpecTypes can be exercised without needing
+// go/packages (and the module/build graph it requires) in a test.
+func checkSource(t *testing.T, src string) *types.Scope {
+	t.Helper()
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "sample.go", src, 0)
+	if err != nil {
+		t.Fatalf("parsing test source: %v", err)
+	}
+	conf := types.Config{Importer: importer.Default()}
+	pkg, err := conf.Check("sample", fset, []*ast.File{f}, nil)
+	if err != nil {
+		t.Fatalf("type-checking test source: %v", err)
+	}
+	return pkg.Scope()
+}
+
+func TestProtoSpecTypes(t *testing.T) {
+	scope := checkSource(t, `
+package sample
+
+// NeedsDeepCopyInto looks like a generated proto message and has no
+// DeepCopyInto yet, so gen should pick it up.
+type NeedsDeepCopyInto struct{}
+
+func (*NeedsDeepCopyInto) ProtoReflect() int { return 0 }
+
+// AlreadyGenerated already has a DeepCopyInto, so gen must not re-emit it.
+type AlreadyGenerated struct{}
+
+func (*AlreadyGenerated) ProtoReflect() int        { return 0 }
+func (*AlreadyGenerated) DeepCopyInto(dst interface{}) {}
+
+// PlainStruct isn't a proto.Message at all.
+type PlainStruct struct{}
+
+// unexported isn't a candidate regardless of its methods.
+type unexported struct{}
+
+func (*unexported) ProtoReflect() int { return 0 }
+`)
+
+	got := protoSpecTypes(scope)
+	want := []string{"NeedsDeepCopyInto"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("protoSpecTypes() = %v, want %v", got, want)
+	}
+}
+
+func TestProtoSpecTypesSortedAndEmpty(t *testing.T) {
+	scope := checkSource(t, `
+package sample
+
+type Zebra struct{}
+func (*Zebra) ProtoReflect() int { return 0 }
+
+type Apple struct{}
+func (*Apple) ProtoReflect() int { return 0 }
+`)
+
+	got := protoSpecTypes(scope)
+	want := []string{"Apple", "Zebra"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("protoSpecTypes() = %v, want %v (sorted)", got, want)
+	}
+
+	empty := checkSource(t, `package sample
+
+type NotAProto struct{}
+`)
+	if got := protoSpecTypes(empty); len(got) != 0 {
+		t.Errorf("protoSpecTypes() = %v, want none", got)
+	}
+}
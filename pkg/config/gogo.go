@@ -0,0 +1,126 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !nogogo
+
+package config
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+
+	gogojsonpb "github.com/gogo/protobuf/jsonpb"
+	gogoproto "github.com/gogo/protobuf/proto"
+	gogotypes "github.com/gogo/protobuf/types"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+// This file is a thin gogo/protobuf compatibility shim kept around for one
+// release cycle while callers migrate from ToProtoGogo to ToProto. Building
+// with the nogogo tag compiles it out entirely, along with the
+// github.com/gogo/protobuf dependency.
+
+func init() {
+	gogoToJSON = func(s Spec) ([]byte, bool, error) {
+		pb, ok := s.(gogoproto.Message)
+		if !ok {
+			return nil, false, nil
+		}
+		b := &bytes.Buffer{}
+		err := (&gogojsonpb.Marshaler{}).Marshal(b, pb)
+		return b.Bytes(), true, err
+	}
+	gogoApplyJSON = func(s Spec, js string) (bool, error) {
+		pb, ok := s.(gogoproto.Message)
+		if !ok {
+			return false, nil
+		}
+		u := gogojsonpb.Unmarshaler{AllowUnknownFields: true}
+		return true, u.Unmarshal(bytes.NewReader([]byte(js)), pb)
+	}
+	gogoApplyJSONStrict = func(s Spec, js string) (bool, error) {
+		pb, ok := s.(gogoproto.Message)
+		if !ok {
+			return false, nil
+		}
+		u := gogojsonpb.Unmarshaler{AllowUnknownFields: false}
+		return true, u.Unmarshal(bytes.NewReader([]byte(js)), pb)
+	}
+	gogoDeepCopy = func(s Spec) (Spec, bool) {
+		pb, ok := s.(gogoproto.Message)
+		if !ok {
+			return nil, false
+		}
+		return gogoproto.Clone(pb), true
+	}
+	gogoToProto = func(s Spec) (*anypb.Any, bool, error) {
+		pb, ok := s.(gogoproto.Message)
+		if !ok {
+			return nil, false, nil
+		}
+		b, err := gogoproto.Marshal(pb)
+		if err != nil {
+			return nil, true, err
+		}
+		return &anypb.Any{
+			TypeUrl: "type.googleapis.com/" + gogoproto.MessageName(pb),
+			Value:   b,
+		}, true, nil
+	}
+	gogoTypeURL = func(s Spec) (string, bool, error) {
+		pb, ok := s.(gogoproto.Message)
+		if !ok {
+			return "", false, nil
+		}
+		name := gogoproto.MessageName(pb)
+		if name == "" {
+			return "", true, fmt.Errorf("config: %T has no registered protobuf type name", s)
+		}
+		return "type.googleapis.com/" + name, true, nil
+	}
+}
+
+// ToProtoGogo marshals s to a gogo/protobuf Any.
+//
+// Deprecated: gogo/protobuf is being removed from Istio. Use ToProto, which
+// returns a google.golang.org/protobuf anypb.Any, instead. ToProtoGogo is
+// kept for one release cycle to ease the migration and is compiled out
+// entirely when built with the nogogo build tag.
+func ToProtoGogo(s Spec) (*gogotypes.Any, error) {
+	if pb, ok := s.(gogoproto.Message); ok {
+		return gogotypes.MarshalAny(pb)
+	}
+
+	js, err := json.Marshal(s)
+	if err != nil {
+		return nil, err
+	}
+	pbs := &gogotypes.Struct{}
+	if err := gogojsonpb.Unmarshal(bytes.NewReader(js), pbs); err != nil {
+		return nil, err
+	}
+	return gogotypes.MarshalAny(pbs)
+}
+
+// ToProtoGogo marshals c.Spec to a gogo/protobuf Any.
+//
+// Deprecated: use Config.ToProto instead.
+func (c Config) ToProtoGogo(ctx context.Context) (*gogotypes.Any, error) {
+	_, span := c.startSpan(ctx, "ToProtoGogo")
+	a, err := ToProtoGogo(c.Spec)
+	endSpan(span, err)
+	return a, err
+}
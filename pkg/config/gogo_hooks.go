@@ -0,0 +1,47 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import "google.golang.org/protobuf/types/known/anypb"
+
+// The functions below are the seam between the canonical
+// google.golang.org/protobuf marshaling path in model.go and the
+// deprecated gogo/protobuf compatibility shim in gogo.go. gogo.go
+// overrides them in an init() unless built with the nogogo build tag, in
+// which case gogo/protobuf Specs are no longer recognized and these
+// fall through to the plain JSON path.
+//
+// handled reports whether s was a gogo/protobuf Spec; when handled is
+// false the returned value/error must be ignored.
+var (
+	gogoToJSON = func(s Spec) (js []byte, handled bool, err error) {
+		return nil, false, nil
+	}
+	gogoApplyJSON = func(s Spec, js string) (handled bool, err error) {
+		return false, nil
+	}
+	gogoApplyJSONStrict = func(s Spec, js string) (handled bool, err error) {
+		return false, nil
+	}
+	gogoDeepCopy = func(s Spec) (clone Spec, handled bool) {
+		return nil, false
+	}
+	gogoToProto = func(s Spec) (a *anypb.Any, handled bool, err error) {
+		return nil, false, nil
+	}
+	gogoTypeURL = func(s Spec) (url string, handled bool, err error) {
+		return "", false, nil
+	}
+)
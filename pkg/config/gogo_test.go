@@ -0,0 +1,65 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !nogogo
+
+package config
+
+import (
+	"strings"
+	"testing"
+
+	gogoproto "github.com/gogo/protobuf/proto"
+	gogotypes "github.com/gogo/protobuf/types"
+)
+
+// TestToProtoGogoSpec verifies that ToProto (the canonical, non-deprecated
+// entry point) still produces a typed Any for a gogo/protobuf-only Spec via
+// the gogoToProto hook, instead of silently falling through to the generic
+// structpb.Struct JSON path (see ToProtoGogo for the deprecated, gogo-typed
+// equivalent).
+func TestToProtoGogoSpec(t *testing.T) {
+	s := &gogotypes.StringValue{Value: "hi"}
+
+	a, err := ToProto(s)
+	if err != nil {
+		t.Fatalf("ToProto(gogo Spec): %v", err)
+	}
+	if !strings.HasSuffix(a.TypeUrl, "google.protobuf.StringValue") {
+		t.Errorf("TypeUrl = %q, want suffix google.protobuf.StringValue", a.TypeUrl)
+	}
+
+	// The generic JSON fallback would have produced a structpb.Struct Any
+	// instead, so decoding the value as a StringValue must round-trip.
+	got := &gogotypes.StringValue{}
+	if err := gogoproto.Unmarshal(a.Value, got); err != nil {
+		t.Fatalf("unmarshaling Any value: %v", err)
+	}
+	if got.Value != s.Value {
+		t.Errorf("round-tripped value = %q, want %q", got.Value, s.Value)
+	}
+}
+
+// TestTypeURLGogoSpec verifies TypeURL's doc claim that callers "don't need
+// to know whether s was produced by the gogo or google.golang.org/protobuf
+// runtime" actually holds for a gogo-only Spec, via the gogoTypeURL hook.
+func TestTypeURLGogoSpec(t *testing.T) {
+	url, err := TypeURL(&gogotypes.StringValue{Value: "hi"})
+	if err != nil {
+		t.Fatalf("TypeURL(gogo Spec): %v", err)
+	}
+	if want := "type.googleapis.com/google.protobuf.StringValue"; url != want {
+		t.Errorf("TypeURL = %q, want %q", url, want)
+	}
+}
@@ -14,24 +14,28 @@
 
 package config
 
+// There are no concrete proto.Message Spec implementations in this
+// package (Spec is just an interface), so running pkg/config/gen here has
+// nothing to generate; it's wired in for local testing of the tool. Real
+// generated-proto packages should add their own go:generate line, naming
+// the tool by its import path istio.io/istio/pkg/config/gen rather than
+// the ./gen path used here, which only resolves from this directory.
+//go:generate go run ./gen -output deepcopy_gen.go .
+
 import (
-	bytes "bytes"
+	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"reflect"
 	"time"
 
 	"github.com/ghodss/yaml"
-	gogojsonpb "github.com/gogo/protobuf/jsonpb"
-	gogoproto "github.com/gogo/protobuf/proto"
-	gogotypes "github.com/gogo/protobuf/types"
-	"github.com/golang/protobuf/jsonpb"
-	"github.com/golang/protobuf/proto"
-	"github.com/golang/protobuf/ptypes"
-	"google.golang.org/protobuf/reflect/protoreflect"
-
-	"istio.io/istio/pkg/util/gogoprotomarshal"
-	"istio.io/istio/pkg/util/protomarshal"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/known/anypb"
+	"google.golang.org/protobuf/types/known/structpb"
 )
 
 // Meta is metadata attached to each configuration unit.
@@ -96,37 +100,57 @@ type Config struct {
 // * Able to marshal/unmarshal using json
 type Spec interface{}
 
-func ToProtoGogo(s Spec) (*gogotypes.Any, error) {
-	// golang protobuf. Use protoreflect.ProtoMessage to distinguish from gogo
-	// golang/protobuf 1.4+ will have this interface. Older golang/protobuf are gogo compatible
-	// but also not used by Istio at all.
-	if _, ok := s.(protoreflect.ProtoMessage); ok {
-		if pb, ok := s.(proto.Message); ok {
-			golangany, err := ptypes.MarshalAny(pb)
-			if err != nil {
-				return nil, err
-			}
-			return &gogotypes.Any{
-				TypeUrl: golangany.TypeUrl,
-				Value:   golangany.Value,
-			}, nil
-		}
+// ToProto marshals s to a google.golang.org/protobuf anypb.Any using the
+// default gogo/golang-proto/JSON codec. Unlike Config.ToProto, this takes
+// no GroupVersionKind, so it never consults the SpecCodec registry: a
+// RegisterCodec override for s's GVK is ignored. Prefer Config.ToProto
+// when a GVK is available.
+func ToProto(s Spec) (*anypb.Any, error) {
+	return toProto(s)
+}
+
+func toProto(s Spec) (*anypb.Any, error) {
+	if pb, ok := s.(proto.Message); ok {
+		return anypb.New(pb)
 	}
 
-	// gogo protobuf
-	if pb, ok := s.(gogoproto.Message); ok {
-		return gogotypes.MarshalAny(pb)
+	// gogo/protobuf, deprecated: see ToProtoGogo.
+	if a, handled, err := gogoToProto(s); handled {
+		return a, err
 	}
 
 	js, err := json.Marshal(s)
 	if err != nil {
 		return nil, err
 	}
-	pbs := &gogotypes.Struct{}
-	if err := gogojsonpb.Unmarshal(bytes.NewReader(js), pbs); err != nil {
+	pbs := &structpb.Struct{}
+	if err := protojson.Unmarshal(js, pbs); err != nil {
 		return nil, err
 	}
-	return gogotypes.MarshalAny(pbs)
+	return anypb.New(pbs)
+}
+
+// TypeURL returns the protobuf type URL for s's underlying message type, so
+// callers don't need to know whether s was produced by the gogo or
+// google.golang.org/protobuf runtime. google.golang.org/protobuf messages
+// are resolved via protoregistry.GlobalTypes; gogo/protobuf messages are
+// resolved from their gogo-registered message name (not via GlobalTypes),
+// and are rejected when built with the nogogo build tag.
+func TypeURL(s Spec) (string, error) {
+	pb, ok := s.(proto.Message)
+	if !ok {
+		// gogo/protobuf, deprecated: see ToProtoGogo.
+		if url, handled, err := gogoTypeURL(s); handled {
+			return url, err
+		}
+		return "", fmt.Errorf("config: %T has no registered protobuf type", s)
+	}
+	name := pb.ProtoReflect().Descriptor().FullName()
+	url := "type.googleapis.com/" + string(name)
+	if _, err := protoregistry.GlobalTypes.FindMessageByURL(url); err != nil {
+		return "", fmt.Errorf("config: resolving type URL for %s: %w", name, err)
+	}
+	return url, nil
 }
 
 func ToMap(s Spec) (map[string]interface{}, error) {
@@ -145,22 +169,22 @@ func ToMap(s Spec) (map[string]interface{}, error) {
 	return data, nil
 }
 
+// ToJSON marshals s to JSON using the default gogo/golang-proto/JSON
+// codec. Unlike Config.ToJSON, this takes no GroupVersionKind, so it
+// never consults the SpecCodec registry: a RegisterCodec override for s's
+// GVK is ignored. Prefer Config.ToJSON when a GVK is available.
 func ToJSON(s Spec) ([]byte, error) {
-	b := &bytes.Buffer{}
-	// golang protobuf. Use protoreflect.ProtoMessage to distinguish from gogo
-	// golang/protobuf 1.4+ will have this interface. Older golang/protobuf are gogo compatible
-	// but also not used by Istio at all.
-	if _, ok := s.(protoreflect.ProtoMessage); ok {
-		if pb, ok := s.(proto.Message); ok {
-			err := (&jsonpb.Marshaler{}).Marshal(b, pb)
-			return b.Bytes(), err
-		}
+	return toJSON(s)
+}
+
+func toJSON(s Spec) ([]byte, error) {
+	if pb, ok := s.(proto.Message); ok {
+		return protojson.Marshal(pb)
 	}
 
-	// gogo protobuf
-	if pb, ok := s.(gogoproto.Message); ok {
-		err := (&gogojsonpb.Marshaler{}).Marshal(b, pb)
-		return b.Bytes(), err
+	// gogo/protobuf, deprecated: see ToProtoGogo.
+	if b, handled, err := gogoToJSON(s); handled {
+		return b, err
 	}
 
 	return json.Marshal(s)
@@ -170,6 +194,16 @@ type deepCopier interface {
 	DeepCopyInterface() interface{}
 }
 
+// deepCopyIntoer is implemented by Specs with a generated DeepCopyInto
+// method (see pkg/config/gen) that copies the Spec into a caller-supplied
+// dst, typically via proto.Merge into a preallocated message. DeepCopy and
+// Config.DeepCopyInto prefer it over deepCopier and proto.Clone, since both
+// of those always allocate a fresh copy, whereas dst can be pooled and
+// reused across push cycles.
+type deepCopyIntoer interface {
+	DeepCopyInto(dst interface{})
+}
+
 func ApplyYAML(s Spec, yml string) error {
 	js, err := yaml.YAMLToJSON([]byte(yml))
 	if err != nil {
@@ -178,20 +212,23 @@ func ApplyYAML(s Spec, yml string) error {
 	return ApplyJSON(s, string(js))
 }
 
+// ApplyJSONStrict unmarshals js into s using the default
+// gogo/golang-proto/JSON codec, rejecting unknown fields regardless of the
+// underlying wire format. Unlike Config.ApplyJSONStrict, this takes no
+// GroupVersionKind, so it never consults the SpecCodec or SchemaValidator
+// registries: a RegisterCodec/RegisterSchema override for s's GVK is
+// ignored. Prefer Config.ApplyJSONStrict when a GVK is available.
 func ApplyJSONStrict(s Spec, js string) error {
-	// golang protobuf. Use protoreflect.ProtoMessage to distinguish from gogo
-	// golang/protobuf 1.4+ will have this interface. Older golang/protobuf are gogo compatible
-	// but also not used by Istio at all.
-	if _, ok := s.(protoreflect.ProtoMessage); ok {
-		if pb, ok := s.(proto.Message); ok {
-			err := protomarshal.ApplyJSONStrict(js, pb)
-			return err
-		}
+	return applyJSONStrict(s, js)
+}
+
+func applyJSONStrict(s Spec, js string) error {
+	if pb, ok := s.(proto.Message); ok {
+		return protojson.Unmarshal([]byte(js), pb)
 	}
 
-	// gogo protobuf
-	if pb, ok := s.(gogoproto.Message); ok {
-		err := gogoprotomarshal.ApplyJSONStrict(js, pb)
+	// gogo/protobuf, deprecated: see ToProtoGogo.
+	if handled, err := gogoApplyJSONStrict(s, js); handled {
 		return err
 	}
 
@@ -200,44 +237,65 @@ func ApplyJSONStrict(s Spec, js string) error {
 	return d.Decode(&s)
 }
 
+// ApplyJSON unmarshals js into s using the default gogo/golang-proto/JSON
+// codec. Unlike Config.ApplyJSON, this takes no GroupVersionKind, so it
+// never consults the SpecCodec registry: a RegisterCodec override for s's
+// GVK is ignored. Prefer Config.ApplyJSON when a GVK is available.
 func ApplyJSON(s Spec, js string) error {
-	// golang protobuf. Use protoreflect.ProtoMessage to distinguish from gogo
-	// golang/protobuf 1.4+ will have this interface. Older golang/protobuf are gogo compatible
-	// but also not used by Istio at all.
-	if _, ok := s.(protoreflect.ProtoMessage); ok {
-		if pb, ok := s.(proto.Message); ok {
-			err := protomarshal.ApplyJSON(js, pb)
-			return err
-		}
+	return applyJSON(s, js)
+}
+
+func applyJSON(s Spec, js string) error {
+	if pb, ok := s.(proto.Message); ok {
+		return protojson.UnmarshalOptions{DiscardUnknown: true}.Unmarshal([]byte(js), pb)
 	}
 
-	// gogo protobuf
-	if pb, ok := s.(gogoproto.Message); ok {
-		err := gogoprotomarshal.ApplyJSON(js, pb)
+	// gogo/protobuf, deprecated: see ToProtoGogo.
+	if handled, err := gogoApplyJSON(s, js); handled {
 		return err
 	}
 
 	return json.Unmarshal([]byte(js), &s)
 }
 
+// DeepCopy clones s using the default gogo/golang-proto/JSON codec.
+// Unlike Config.DeepCopy, this takes no GroupVersionKind, so it never
+// consults the SpecCodec registry: a RegisterCodec override for s's GVK
+// is ignored. Prefer Config.DeepCopy when a GVK is available.
 func DeepCopy(s Spec) Spec {
+	return deepCopy(s)
+}
+
+func deepCopy(s Spec) Spec {
+	return deepCopyWithDst(s, nil)
+}
+
+// deepCopyWithDst deep-copies s, writing into dst when s implements
+// deepCopyIntoer and dst is non-nil, to avoid allocating a fresh copy.
+// If s implements deepCopyIntoer but dst is nil, a zero-value destination
+// of s's concrete type is allocated so DeepCopyInto can still be used in
+// preference to deepCopier/proto.Clone.
+func deepCopyWithDst(s Spec, dst Spec) Spec {
+	if dc, ok := s.(deepCopyIntoer); ok {
+		if dst == nil {
+			dst = reflect.New(reflect.TypeOf(s).Elem()).Interface()
+		}
+		dc.DeepCopyInto(dst)
+		return dst
+	}
+
 	// If deep copy is defined, use that
 	if dc, ok := s.(deepCopier); ok {
 		return dc.DeepCopyInterface()
 	}
 
-	// golang protobuf. Use protoreflect.ProtoMessage to distinguish from gogo
-	// golang/protobuf 1.4+ will have this interface. Older golang/protobuf are gogo compatible
-	// but also not used by Istio at all.
-	if _, ok := s.(protoreflect.ProtoMessage); ok {
-		if pb, ok := s.(proto.Message); ok {
-			return proto.Clone(pb)
-		}
+	if pb, ok := s.(proto.Message); ok {
+		return proto.Clone(pb)
 	}
 
-	// gogo protobuf
-	if pb, ok := s.(gogoproto.Message); ok {
-		return gogoproto.Clone(pb)
+	// gogo/protobuf, deprecated: see ToProtoGogo.
+	if clone, handled := gogoDeepCopy(s); handled {
+		return clone
 	}
 
 	// If we don't have a deep copy method, we will have to do some reflection magic. Its not ideal,
@@ -267,7 +325,27 @@ func (meta *Meta) Key() string {
 	return Key(meta.GroupVersionKind.Kind, meta.Name, meta.Namespace)
 }
 
-func (c Config) DeepCopy() Config {
+// DeepCopy returns a deep copy of c. It always allocates a new Spec; to
+// reuse an allocation across push cycles (e.g. from a sync.Pool), use
+// DeepCopyInto instead. ctx is used only to parent the tracing span this
+// opens (see SetTracerProvider); pass context.Background() if the caller
+// has no active trace.
+func (c Config) DeepCopy(ctx context.Context) Config {
+	return c.deepCopy(ctx, nil)
+}
+
+// DeepCopyInto deep-copies c like DeepCopy, but writes the cloned Spec into
+// dst instead of allocating a new one, provided c.Spec implements the
+// generated DeepCopyInto(interface{}) method and dst was not overridden by
+// a custom SpecCodec registered for c.GroupVersionKind. dst is ignored,
+// and a fresh Spec is allocated as usual, when either condition doesn't
+// hold.
+func (c Config) DeepCopyInto(ctx context.Context, dst Spec) Config {
+	return c.deepCopy(ctx, dst)
+}
+
+func (c Config) deepCopy(ctx context.Context, dst Spec) Config {
+	_, span := c.startSpan(ctx, "DeepCopy")
 	var clone Config
 	clone.Meta = c.Meta
 	if c.Labels != nil {
@@ -282,10 +360,73 @@ func (c Config) DeepCopy() Config {
 			clone.Annotations[k] = v
 		}
 	}
-	clone.Spec = DeepCopy(c.Spec)
+	if dst != nil && codecIsDefault(c.GroupVersionKind) {
+		clone.Spec = deepCopyWithDst(c.Spec, dst)
+	} else {
+		clone.Spec = codecFor(c.GroupVersionKind).DeepCopy(c.Spec)
+	}
+	endSpan(span, nil)
 	return clone
 }
 
+// ToJSON marshals c.Spec using the SpecCodec registered for
+// c.GroupVersionKind, if any, otherwise the default codec. ctx is used
+// only to parent the tracing span this opens (see SetTracerProvider); pass
+// context.Background() if the caller has no active trace.
+func (c Config) ToJSON(ctx context.Context) ([]byte, error) {
+	_, span := c.startSpan(ctx, "ToJSON")
+	js, err := codecFor(c.GroupVersionKind).ToJSON(c.Spec)
+	endSpan(span, err)
+	return js, err
+}
+
+// ApplyJSON unmarshals js into c.Spec using the SpecCodec registered for
+// c.GroupVersionKind, if any, otherwise the default codec. ctx is used
+// only to parent the tracing span this opens (see SetTracerProvider); pass
+// context.Background() if the caller has no active trace.
+func (c Config) ApplyJSON(ctx context.Context, js string) error {
+	_, span := c.startSpan(ctx, "ApplyJSON")
+	err := codecFor(c.GroupVersionKind).ApplyJSON(c.Spec, js)
+	endSpan(span, err)
+	return err
+}
+
+// ApplyJSONStrict unmarshals js into c.Spec using the SpecCodec registered
+// for c.GroupVersionKind, if any, otherwise the default codec, rejecting
+// unknown fields regardless of the underlying wire format. If a
+// SchemaValidator is registered for c.GroupVersionKind via RegisterSchema,
+// it is consulted first and its *ValidationError, with field paths,
+// expected types and suggestions, is returned in place of whatever error
+// the underlying codec would have produced. ctx is used only to parent
+// the tracing span this opens (see SetTracerProvider); pass
+// context.Background() if the caller has no active trace.
+func (c Config) ApplyJSONStrict(ctx context.Context, js string) error {
+	_, span := c.startSpan(ctx, "ApplyJSONStrict")
+	var err error
+	if schema, ok := schemaFor(c.GroupVersionKind); ok {
+		if verr := schema.Validate([]byte(js)); verr != nil {
+			err = verr
+			endSpan(span, err)
+			return err
+		}
+	}
+	err = codecFor(c.GroupVersionKind).ApplyJSONStrict(c.Spec, js)
+	endSpan(span, err)
+	return err
+}
+
+// ToProto marshals c.Spec to a google.golang.org/protobuf anypb.Any using
+// the SpecCodec registered for c.GroupVersionKind, if any, otherwise the
+// default codec. ctx is used only to parent the tracing span this opens
+// (see SetTracerProvider); pass context.Background() if the caller has no
+// active trace.
+func (c Config) ToProto(ctx context.Context) (*anypb.Any, error) {
+	_, span := c.startSpan(ctx, "ToProto")
+	a, err := codecFor(c.GroupVersionKind).ToProto(c.Spec)
+	endSpan(span, err)
+	return a, err
+}
+
 var _ fmt.Stringer = GroupVersionKind{}
 
 type GroupVersionKind struct {
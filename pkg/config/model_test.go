@@ -0,0 +1,117 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"strings"
+	"testing"
+
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// jsonOnlySpec is a Spec that is neither a golang/protobuf nor a gogo/protobuf
+// Message, exercising the encoding/json fallback path of toJSON/toProto/etc.
+type jsonOnlySpec struct {
+	Foo string `json:"foo"`
+}
+
+func TestToProto(t *testing.T) {
+	t.Run("proto.Message", func(t *testing.T) {
+		a, err := ToProto(&emptypb.Empty{})
+		if err != nil {
+			t.Fatalf("ToProto(emptypb.Empty{}): %v", err)
+		}
+		if !strings.HasSuffix(a.TypeUrl, "google.protobuf.Empty") {
+			t.Errorf("TypeUrl = %q, want suffix google.protobuf.Empty", a.TypeUrl)
+		}
+	})
+
+	t.Run("json fallback", func(t *testing.T) {
+		a, err := ToProto(&jsonOnlySpec{Foo: "bar"})
+		if err != nil {
+			t.Fatalf("ToProto(jsonOnlySpec): %v", err)
+		}
+		pbs := &structpb.Struct{}
+		if err := a.UnmarshalTo(pbs); err != nil {
+			t.Fatalf("unmarshaling fallback Any: %v", err)
+		}
+		if got := pbs.Fields["foo"].GetStringValue(); got != "bar" {
+			t.Errorf("fallback Any fields[foo] = %q, want %q", got, "bar")
+		}
+	})
+}
+
+func TestTypeURL(t *testing.T) {
+	url, err := TypeURL(&emptypb.Empty{})
+	if err != nil {
+		t.Fatalf("TypeURL(emptypb.Empty{}): %v", err)
+	}
+	if want := "type.googleapis.com/google.protobuf.Empty"; url != want {
+		t.Errorf("TypeURL = %q, want %q", url, want)
+	}
+
+	if _, err := TypeURL(&jsonOnlySpec{}); err == nil {
+		t.Error("TypeURL(jsonOnlySpec{}) = nil error, want error for a non-proto.Message Spec")
+	}
+}
+
+func TestApplyJSONStrictRejectsUnknownFields(t *testing.T) {
+	t.Run("proto.Message", func(t *testing.T) {
+		if err := ApplyJSONStrict(&emptypb.Empty{}, `{"unknown":1}`); err == nil {
+			t.Error("ApplyJSONStrict with unknown field = nil error, want error")
+		}
+	})
+
+	t.Run("json fallback", func(t *testing.T) {
+		s := &jsonOnlySpec{}
+		if err := ApplyJSONStrict(s, `{"foo":"bar","unknown":1}`); err == nil {
+			t.Error("ApplyJSONStrict with unknown field = nil error, want error")
+		}
+	})
+}
+
+func TestApplyJSONIgnoresUnknownFields(t *testing.T) {
+	t.Run("proto.Message", func(t *testing.T) {
+		if err := ApplyJSON(&emptypb.Empty{}, `{"unknown":1}`); err != nil {
+			t.Errorf("ApplyJSON with unknown field: %v, want no error (lenient)", err)
+		}
+	})
+
+	t.Run("json fallback", func(t *testing.T) {
+		s := &jsonOnlySpec{}
+		if err := ApplyJSON(s, `{"foo":"bar","unknown":1}`); err != nil {
+			t.Fatalf("ApplyJSON: %v", err)
+		}
+		if s.Foo != "bar" {
+			t.Errorf("s.Foo = %q, want %q", s.Foo, "bar")
+		}
+	})
+}
+
+func TestDeepCopyJSONFallback(t *testing.T) {
+	s := &jsonOnlySpec{Foo: "bar"}
+	clone, ok := DeepCopy(s).(*jsonOnlySpec)
+	if !ok {
+		t.Fatalf("DeepCopy(jsonOnlySpec) returned %T, want *jsonOnlySpec", DeepCopy(s))
+	}
+	if clone.Foo != s.Foo {
+		t.Errorf("clone.Foo = %q, want %q", clone.Foo, s.Foo)
+	}
+	clone.Foo = "changed"
+	if s.Foo == clone.Foo {
+		t.Error("DeepCopy did not produce an independent copy")
+	}
+}
@@ -0,0 +1,102 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"strings"
+	"sync"
+)
+
+// SchemaValidator checks raw JSON against a structural schema for a
+// GroupVersionKind before it is unmarshaled into a Spec. Implementations
+// may be backed by an OpenAPI v3 document or a CUE definition; RegisterSchema
+// itself doesn't care which, so long as Validate reports every violation it
+// finds rather than stopping at the first one.
+//
+// This package only ships the registry and the SchemaValidator/ValidationError
+// contract: no OpenAPI- or CUE-backed implementation, and no
+// pilot/pkg/config/kube/crd wiring, is included here. Callers that want
+// schema validation must provide their own SchemaValidator (e.g. one backed
+// by gnostic or cuelang.org/go) and call RegisterSchema with it.
+type SchemaValidator interface {
+	// Validate checks js against the schema and returns a *ValidationError
+	// describing every violation, or nil if js conforms.
+	Validate(js []byte) *ValidationError
+}
+
+// FieldError describes a single structural validation failure.
+type FieldError struct {
+	// Path is the field path at which the violation occurred, e.g.
+	// "spec.http[0].route.destination.host".
+	Path string
+	// Expected names the type or constraint that was violated, e.g.
+	// "string" or "required".
+	Expected string
+	// Suggestion is an optional human-readable hint for fixing the error.
+	Suggestion string
+}
+
+func (f FieldError) String() string {
+	s := f.Path + ": expected " + f.Expected
+	if f.Suggestion != "" {
+		s += " (" + f.Suggestion + ")"
+	}
+	return s
+}
+
+// ValidationError reports every structural schema violation a SchemaValidator
+// found for a Config's GroupVersionKind. Callers get field paths, expected
+// types and suggestions instead of the opaque "unknown field" errors that
+// protobuf unmarshaling alone can produce.
+type ValidationError struct {
+	GVK    GroupVersionKind
+	Fields []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	msgs := make([]string, 0, len(e.Fields))
+	for _, f := range e.Fields {
+		msgs = append(msgs, f.String())
+	}
+	return e.GVK.String() + " failed schema validation: " + strings.Join(msgs, "; ")
+}
+
+var (
+	schemaMu sync.RWMutex
+	schemas  = map[GroupVersionKind]SchemaValidator{}
+)
+
+// RegisterSchema installs schema as the structural validator consulted by
+// Config.ApplyJSONStrict for the given GroupVersionKind. The schema runs
+// against the raw JSON before it reaches the registered SpecCodec, so
+// malformed input is rejected with field-level detail rather than a
+// protobuf unmarshal error. Calling RegisterSchema again for the same gvk
+// replaces the previously registered schema.
+//
+// schema is caller-supplied: see the doc comment on SchemaValidator for why
+// this package doesn't construct one for you.
+func RegisterSchema(gvk GroupVersionKind, schema SchemaValidator) {
+	schemaMu.Lock()
+	defer schemaMu.Unlock()
+	schemas[gvk] = schema
+}
+
+// schemaFor returns the SchemaValidator registered for gvk, if any.
+func schemaFor(gvk GroupVersionKind) (SchemaValidator, bool) {
+	schemaMu.RLock()
+	defer schemaMu.RUnlock()
+	s, ok := schemas[gvk]
+	return s, ok
+}
@@ -0,0 +1,93 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestValidationErrorFormatting(t *testing.T) {
+	err := &ValidationError{
+		GVK: GroupVersionKind{Group: "test", Version: "v1", Kind: "Fake"},
+		Fields: []FieldError{
+			{Path: "spec.host", Expected: "string"},
+			{Path: "spec.port", Expected: "int", Suggestion: "did you mean spec.number?"},
+		},
+	}
+
+	got := err.Error()
+	want := "test/v1/Fake failed schema validation: " +
+		"spec.host: expected string; spec.port: expected int (did you mean spec.number?)"
+	if got != want {
+		t.Errorf("Error() = %q, want %q", got, want)
+	}
+}
+
+// rejectAllSchema is a SchemaValidator that always fails, used to verify
+// that it runs, and wins, ahead of the SpecCodec.
+type rejectAllSchema struct {
+	called bool
+}
+
+func (r *rejectAllSchema) Validate(js []byte) *ValidationError {
+	r.called = true
+	return &ValidationError{
+		GVK:    GroupVersionKind{Kind: "Fake"},
+		Fields: []FieldError{{Path: "spec", Expected: "schema to hold"}},
+	}
+}
+
+func TestApplyJSONStrictConsultsSchemaBeforeCodec(t *testing.T) {
+	gvk := GroupVersionKind{Kind: "Fake"}
+	schema := &rejectAllSchema{}
+	RegisterSchema(gvk, schema)
+	t.Cleanup(func() {
+		schemaMu.Lock()
+		delete(schemas, gvk)
+		schemaMu.Unlock()
+	})
+
+	c := Config{Meta: Meta{GroupVersionKind: gvk}, Spec: &jsonOnlySpec{Foo: "bar"}}
+	err := c.ApplyJSONStrict(context.Background(), `{"foo":"baz"}`)
+	if err == nil {
+		t.Fatal("ApplyJSONStrict = nil error, want *ValidationError from the registered schema")
+	}
+	if !schema.called {
+		t.Error("SchemaValidator.Validate was never called")
+	}
+	if _, ok := err.(*ValidationError); !ok {
+		t.Errorf("error type = %T, want *ValidationError", err)
+	}
+	if !strings.Contains(err.Error(), "schema to hold") {
+		t.Errorf("error = %q, want it to surface the ValidationError detail", err.Error())
+	}
+
+	// The Spec must be untouched: the codec never ran since the schema
+	// rejected js first.
+	if c.Spec.(*jsonOnlySpec).Foo != "bar" {
+		t.Error("Spec was mutated even though schema validation rejected the input")
+	}
+}
+
+func TestApplyJSONStrictWithoutSchemaFallsThroughToCodec(t *testing.T) {
+	gvk := GroupVersionKind{Kind: "NoSchema"}
+	c := Config{Meta: Meta{GroupVersionKind: gvk}, Spec: &jsonOnlySpec{}}
+
+	if err := c.ApplyJSONStrict(context.Background(), `{"unknown":1}`); err == nil {
+		t.Error("ApplyJSONStrict with unknown field and no registered schema = nil error, want the codec's own strict-mode error")
+	}
+}
@@ -0,0 +1,68 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"context"
+	"fmt"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracer is used to open the spans documented on SetTracerProvider. It
+// defaults to whatever global TracerProvider is installed (a no-op unless
+// the binary configures one), so config marshaling carries no tracing
+// overhead until an embedder opts in.
+var tracer = otel.Tracer("istio.io/istio/pkg/config")
+
+// SetTracerProvider installs tp as the source of the tracer used for the
+// config.ToJSON, config.ApplyJSON, config.ApplyJSONStrict, config.ToProto
+// and config.DeepCopy spans opened by Config's marshaling methods. Each
+// span is tagged with the Config's GroupVersionKind, namespace, name,
+// resourceVersion and concrete Spec type, and records the returned error,
+// if any. Each span is opened as a child of the ctx passed to the
+// corresponding Config method, so it attributes into whatever trace the
+// caller (e.g. pilot's push cycle) already has in flight. Translation is
+// on the hot path of that push cycle, so embedders that don't want the
+// (normally negligible) span overhead, or that want to route it to their
+// own collector, can call this before any Config is marshaled; passing a
+// no-op TracerProvider disables tracing.
+func SetTracerProvider(tp trace.TracerProvider) {
+	tracer = tp.Tracer("istio.io/istio/pkg/config")
+}
+
+// startSpan opens a span named "config.<op>", as a child of ctx, tagged
+// with c's identity and the concrete type of c.Spec. The caller must call
+// endSpan with the error the traced operation returned.
+func (c Config) startSpan(ctx context.Context, op string) (context.Context, trace.Span) {
+	return tracer.Start(ctx, "config."+op, trace.WithAttributes(
+		attribute.String("gvk", c.GroupVersionKind.String()),
+		attribute.String("namespace", c.Namespace),
+		attribute.String("name", c.Name),
+		attribute.String("resourceVersion", c.ResourceVersion),
+		attribute.String("spec.type", fmt.Sprintf("%T", c.Spec)),
+	))
+}
+
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
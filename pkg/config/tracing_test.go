@@ -0,0 +1,104 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"context"
+	"testing"
+
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// setupTestTracer installs an in-memory exporter as the TracerProvider for
+// the duration of the test and restores the previous tracer on cleanup.
+func setupTestTracer(t *testing.T) *tracetest.InMemoryExporter {
+	t.Helper()
+	exp := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exp))
+	prev := tracer
+	SetTracerProvider(tp)
+	t.Cleanup(func() { tracer = prev })
+	return exp
+}
+
+func TestConfigSpansAreChildrenOfCallerContext(t *testing.T) {
+	exp := setupTestTracer(t)
+
+	tp := sdktrace.NewTracerProvider()
+	ctx, parent := tp.Tracer("test").Start(context.Background(), "caller")
+	defer parent.End()
+
+	c := Config{Meta: Meta{
+		GroupVersionKind: GroupVersionKind{Kind: "Fake"},
+		Namespace:        "ns",
+		Name:             "name",
+		ResourceVersion:  "1",
+	}, Spec: &jsonOnlySpec{Foo: "bar"}}
+
+	if _, err := c.ToJSON(ctx); err != nil {
+		t.Fatalf("ToJSON: %v", err)
+	}
+
+	spans := exp.GetSpans()
+	if len(spans) != 1 {
+		t.Fatalf("got %d spans, want 1", len(spans))
+	}
+	span := spans[0]
+	if span.Name != "config.ToJSON" {
+		t.Errorf("span name = %q, want %q", span.Name, "config.ToJSON")
+	}
+	if span.Parent.SpanID() != parent.SpanContext().SpanID() {
+		t.Errorf("span parent = %v, want %v (caller's span)", span.Parent.SpanID(), parent.SpanContext().SpanID())
+	}
+
+	attrs := map[string]string{}
+	for _, a := range span.Attributes {
+		attrs[string(a.Key)] = a.Value.AsString()
+	}
+	want := map[string]string{
+		"gvk":             "core//Fake",
+		"namespace":       "ns",
+		"name":            "name",
+		"resourceVersion": "1",
+		"spec.type":       "*config.jsonOnlySpec",
+	}
+	for k, v := range want {
+		if attrs[k] != v {
+			t.Errorf("attribute %s = %q, want %q", k, attrs[k], v)
+		}
+	}
+}
+
+func TestConfigSpanRecordsError(t *testing.T) {
+	exp := setupTestTracer(t)
+
+	c := Config{Meta: Meta{GroupVersionKind: GroupVersionKind{Kind: "Fake"}}, Spec: &jsonOnlySpec{}}
+	if err := c.ApplyJSONStrict(context.Background(), `{"unknown":1}`); err == nil {
+		t.Fatal("ApplyJSONStrict with unknown field = nil error, want error")
+	}
+
+	spans := exp.GetSpans()
+	if len(spans) != 1 {
+		t.Fatalf("got %d spans, want 1", len(spans))
+	}
+	if spans[0].Status.Code != codes.Error {
+		t.Errorf("span status = %v, want codes.Error", spans[0].Status.Code)
+	}
+	if len(spans[0].Events) == 0 {
+		t.Error("span has no recorded error event")
+	}
+}